@@ -0,0 +1,342 @@
+// Package arc implements an ARC (Adaptive Replacement Cache).
+//
+// ARC splits its capacity between a recency list T1 (recently seen
+// once) and a frequency list T2 (seen more than once), backed by ghost
+// lists B1 and B2 that remember the keys of recently evicted entries
+// without their values. Hits against the ghost lists adapt a target
+// size p for T1, so the cache shifts weight toward whichever of
+// recency or frequency has been paying off lately.
+// arc包实现了自适应替换缓存(ARC)：将容量分为最近访问一次的T1与多次访问的T2两个列表，
+// 并用幽灵列表B1、B2记录最近被淘汰对象的键（不含值）；命中幽灵列表会调整T1的目标大小p，
+// 使缓存根据近期的访问模式在“偏向最近”和“偏向频繁”之间自适应
+package arc
+
+import (
+	"container/list"
+
+	"github.com/ironzhang/groupcache/internal/cache"
+)
+
+// var _ cache.Interface = (*Cache)(nil) asserts that Cache satisfies
+// the shared cache.Interface, so code parameterized over the interface
+// can use it alongside lru.Cache and lfu.Cache.
+var _ cache.Interface = (*Cache)(nil)
+
+// A Key may be any value that is comparable. See http://golang.org/ref/spec#Comparison_operators
+type Key = interface{}
+
+// EvictReason indicates why an entry was removed from the cache.
+// EvictReason表示缓存对象被移除的原因
+type EvictReason int
+
+const (
+	// EvictedCapacity means the entry was evicted from T1 or T2 to make
+	// room for a new one under MaxEntries.
+	// EvictedCapacity表示因超过MaxEntries而从T1或T2中被淘汰
+	EvictedCapacity EvictReason = iota
+
+	// EvictedRemoved means the entry was removed explicitly via Remove.
+	// EvictedRemoved表示因调用Remove而被移除
+	EvictedRemoved
+)
+
+// listID identifies which of the four lists an entry currently lives
+// in.
+type listID int
+
+const (
+	idT1 listID = iota
+	idT2
+	idB1
+	idB2
+)
+
+// entry backs an element of T1, T2, B1 or B2. Ghost entries (B1/B2)
+// carry a zero value; only the key is meaningful there.
+type entry struct {
+	key   Key
+	value interface{}
+	in    listID
+}
+
+// Cache is an ARC cache. It is not safe for concurrent access.
+type Cache struct {
+	// MaxEntries is the total capacity c shared between T1 and T2.
+	// Zero means no limit is enforced (Add never evicts).
+	MaxEntries int
+
+	// OnEvicted optionally specificies a callback function to be
+	// executed when an entry is purged from the cache (T1 or T2, not
+	// the ghost lists).
+	OnEvicted func(key Key, value interface{}, reason EvictReason)
+
+	p int // target size for T1, adapted on ghost hits
+
+	t1, t2, b1, b2 *list.List
+	cache          map[interface{}]*list.Element // key -> element, in t1 or t2
+	ghost          map[interface{}]*list.Element // key -> element, in b1 or b2
+}
+
+// New creates a new Cache with total capacity maxEntries, shared
+// between T1 and T2.
+func New(maxEntries int) *Cache {
+	return &Cache{
+		MaxEntries: maxEntries,
+		t1:         list.New(),
+		t2:         list.New(),
+		b1:         list.New(),
+		b2:         list.New(),
+		cache:      make(map[interface{}]*list.Element),
+		ghost:      make(map[interface{}]*list.Element),
+	}
+}
+
+func (c *Cache) lazyInit() {
+	if c.cache == nil {
+		c.t1, c.t2, c.b1, c.b2 = list.New(), list.New(), list.New(), list.New()
+		c.cache = make(map[interface{}]*list.Element)
+		c.ghost = make(map[interface{}]*list.Element)
+	}
+}
+
+// Add adds a value to the cache, following the ARC miss/hit paths
+// described in Megiddo & Modha's algorithm.
+func (c *Cache) Add(key Key, value interface{}) {
+	c.lazyInit()
+	capacity := c.MaxEntries
+
+	// 情形一：键已在T1或T2中，更新值并计入一次访问（移入T2）
+	if ele, ok := c.cache[key]; ok {
+		en := ele.Value.(*entry)
+		en.value = value
+		c.promote(key, ele)
+		return
+	}
+
+	// 情形二：键命中幽灵列表B1，偏向recency，增大p
+	if ele, ok := c.ghost[key]; ok && ele.Value.(*entry).in == idB1 {
+		if capacity > 0 {
+			delta := 1
+			if c.b1.Len() > 0 && c.b2.Len() > c.b1.Len() {
+				delta = c.b2.Len() / c.b1.Len()
+			}
+			c.p = min(capacity, c.p+delta)
+			c.replace(key)
+		}
+		c.b1.Remove(ele)
+		delete(c.ghost, key)
+		c.insertT2(key, value)
+		return
+	}
+
+	// 情形三：键命中幽灵列表B2，偏向frequency，减小p
+	if ele, ok := c.ghost[key]; ok && ele.Value.(*entry).in == idB2 {
+		if capacity > 0 {
+			delta := 1
+			if c.b2.Len() > 0 && c.b1.Len() > c.b2.Len() {
+				delta = c.b1.Len() / c.b2.Len()
+			}
+			c.p = max(0, c.p-delta)
+			c.replace(key)
+		}
+		c.b2.Remove(ele)
+		delete(c.ghost, key)
+		c.insertT2(key, value)
+		return
+	}
+
+	// 情形四：全新的键
+	if capacity > 0 {
+		l1 := c.t1.Len() + c.b1.Len()
+		if l1 == capacity {
+			if c.t1.Len() < capacity {
+				c.evictGhost(c.b1)
+				c.replace(key)
+			} else {
+				c.evictFront(c.t1)
+			}
+		} else if l1 < capacity && l1+c.t2.Len()+c.b2.Len() >= capacity {
+			if l1+c.t2.Len()+c.b1.Len()+c.b2.Len() == 2*capacity {
+				c.evictGhost(c.b2)
+			}
+			c.replace(key)
+		}
+	}
+	c.insertT1(key, value)
+}
+
+// Get looks up a key's value from the cache. A hit in T1 promotes the
+// entry to T2 (it has now been seen more than once); a hit in T2 keeps
+// it there, moved to the front.
+func (c *Cache) Get(key Key) (value interface{}, ok bool) {
+	if c.cache == nil {
+		return
+	}
+	ele, hit := c.cache[key]
+	if !hit {
+		return
+	}
+	en := ele.Value.(*entry)
+	value = en.value
+	c.promote(key, ele)
+	return value, true
+}
+
+// promote moves the entry at ele to the front of T2 (it is now either
+// a repeat access from T1, or a re-access already in T2).
+func (c *Cache) promote(key Key, ele *list.Element) {
+	en := ele.Value.(*entry)
+	switch en.in {
+	case idT1:
+		c.t1.Remove(ele)
+	case idT2:
+		c.t2.Remove(ele)
+	}
+	en.in = idT2
+	c.cache[key] = c.t2.PushFront(en)
+}
+
+func (c *Cache) insertT1(key Key, value interface{}) {
+	en := &entry{key: key, value: value, in: idT1}
+	c.cache[key] = c.t1.PushFront(en)
+}
+
+func (c *Cache) insertT2(key Key, value interface{}) {
+	en := &entry{key: key, value: value, in: idT2}
+	c.cache[key] = c.t2.PushFront(en)
+}
+
+// replace evicts one entry from T1 or T2 into the matching ghost list,
+// based on the current size of T1 relative to the target p.
+// replace根据T1当前大小与目标值p的关系，从T1或T2中淘汰一个对象移入对应的幽灵列表
+func (c *Cache) replace(key Key) {
+	if c.t1.Len() > 0 && (c.t1.Len() > c.p || (c.t1.Len() == c.p && c.isGhostHitB2(key))) {
+		c.evictTo(c.t1, c.b1, idB1)
+		return
+	}
+	if c.t2.Len() > 0 {
+		c.evictTo(c.t2, c.b2, idB2)
+		return
+	}
+	if c.t1.Len() > 0 {
+		c.evictTo(c.t1, c.b1, idB1)
+	}
+}
+
+// isGhostHitB2 reports whether key is the key currently triggering a
+// B2 ghost hit, used to break the T1-vs-p tie the same way the
+// reference algorithm does.
+func (c *Cache) isGhostHitB2(key Key) bool {
+	ele, ok := c.ghost[key]
+	return ok && ele.Value.(*entry).in == idB2
+}
+
+// evictTo moves the LRU item of from into the front of to as a ghost
+// entry, firing OnEvicted for real (non-ghost) evictions.
+func (c *Cache) evictTo(from, to *list.List, ghostID listID) {
+	back := from.Back()
+	if back == nil {
+		return
+	}
+	en := back.Value.(*entry)
+	from.Remove(back)
+	delete(c.cache, en.key)
+	if c.OnEvicted != nil {
+		c.OnEvicted(en.key, en.value, EvictedCapacity)
+	}
+	ghost := &entry{key: en.key, in: ghostID}
+	c.ghost[en.key] = to.PushFront(ghost)
+}
+
+// evictFront drops the LRU item of from entirely, without adding it to
+// a ghost list (used when T1 alone fills the capacity and B1 is empty,
+// and by RemoveOldest).
+func (c *Cache) evictFront(from *list.List) {
+	back := from.Back()
+	if back == nil {
+		return
+	}
+	en := back.Value.(*entry)
+	from.Remove(back)
+	delete(c.cache, en.key)
+	if c.OnEvicted != nil {
+		c.OnEvicted(en.key, en.value, EvictedCapacity)
+	}
+}
+
+// evictGhost drops the LRU key of a ghost list, making room for a new
+// ghost entry.
+func (c *Cache) evictGhost(ghostList *list.List) {
+	back := ghostList.Back()
+	if back == nil {
+		return
+	}
+	en := back.Value.(*entry)
+	ghostList.Remove(back)
+	delete(c.ghost, en.key)
+}
+
+// Remove removes the provided key from the cache (T1 or T2; ghost
+// entries are left to age out naturally).
+func (c *Cache) Remove(key Key) {
+	if c.cache == nil {
+		return
+	}
+	ele, hit := c.cache[key]
+	if !hit {
+		return
+	}
+	en := ele.Value.(*entry)
+	switch en.in {
+	case idT1:
+		c.t1.Remove(ele)
+	case idT2:
+		c.t2.Remove(ele)
+	}
+	delete(c.cache, key)
+	if c.OnEvicted != nil {
+		c.OnEvicted(en.key, en.value, EvictedRemoved)
+	}
+}
+
+// RemoveOldest removes the entry the replace step would have chosen,
+// i.e. the LRU item of T1 or T2 depending on |T1| vs p.
+func (c *Cache) RemoveOldest() {
+	if c.cache == nil {
+		return
+	}
+	if c.t1.Len() > 0 && c.t1.Len() > c.p {
+		c.evictFront(c.t1)
+		return
+	}
+	if c.t2.Len() > 0 {
+		c.evictFront(c.t2)
+		return
+	}
+	if c.t1.Len() > 0 {
+		c.evictFront(c.t1)
+	}
+}
+
+// Len returns the number of items currently cached (T1 plus T2; the
+// ghost lists hold no values and don't count).
+func (c *Cache) Len() int {
+	if c.cache == nil {
+		return 0
+	}
+	return c.t1.Len() + c.t2.Len()
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,66 @@
+package arc
+
+import "testing"
+
+func TestAddGet(t *testing.T) {
+	c := New(2)
+	c.Add("a", 1)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %v, %v, want 1, true", v, ok)
+	}
+	if _, ok := c.Get("missing"); ok {
+		t.Fatalf("Get(missing) = _, true, want false")
+	}
+}
+
+// TestCapacityEviction checks that adding beyond MaxEntries keeps the
+// cache within capacity and fires OnEvicted with EvictedCapacity.
+func TestCapacityEviction(t *testing.T) {
+	c := New(2)
+	var reasons []EvictReason
+	c.OnEvicted = func(key Key, value interface{}, reason EvictReason) {
+		reasons = append(reasons, reason)
+	}
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Add("c", 3)
+
+	if c.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", c.Len())
+	}
+	for _, r := range reasons {
+		if r != EvictedCapacity {
+			t.Fatalf("eviction reason = %v, want EvictedCapacity", r)
+		}
+	}
+}
+
+func TestEvictedKeyIsGone(t *testing.T) {
+	c := New(2)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Add("c", 3) // evicts "a"
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatalf("Get(a) = _, true after eviction, want false")
+	}
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Fatalf("Get(c) = %v, %v, want 3, true", v, ok)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	c := New(0)
+	c.Add("a", 1)
+	var reason EvictReason
+	c.OnEvicted = func(key Key, value interface{}, r EvictReason) {
+		reason = r
+	}
+	c.Remove("a")
+	if reason != EvictedRemoved {
+		t.Fatalf("OnEvicted reason = %v, want EvictedRemoved", reason)
+	}
+	if c.Len() != 0 {
+		t.Fatalf("Len() = %d, want 0", c.Len())
+	}
+}
@@ -0,0 +1,34 @@
+// Package cache defines the interface shared by the cache eviction
+// policies (lru, lfu, arc) so that surrounding groupcache code can be
+// parameterized by policy instead of hard-coding lru.Cache.
+// cache包定义了lru、lfu、arc三种淘汰策略共享的接口，使上层代码可以按策略参数化而非写死lru.Cache
+package cache
+
+// Key may be any value that is comparable. See
+// http://golang.org/ref/spec#Comparison_operators
+//
+// Key is a type alias so that lru.Key, lfu.Key and arc.Key all identify
+// exactly the same type and their Cache implementations satisfy
+// Interface without any adaptation.
+// Key是一个类型别名，使得lru.Key、lfu.Key与arc.Key是同一个类型，三者的Cache实现无需适配即可满足Interface
+type Key = interface{}
+
+// Interface is satisfied by lru.Cache, lfu.Cache and arc.Cache.
+// Interface由lru.Cache、lfu.Cache与arc.Cache共同实现
+type Interface interface {
+	// Add adds a value to the cache under key.
+	Add(key Key, value interface{})
+
+	// Get looks up a key's value from the cache.
+	Get(key Key) (value interface{}, ok bool)
+
+	// Remove removes the provided key from the cache.
+	Remove(key Key)
+
+	// RemoveOldest removes the entry chosen by the policy's own notion
+	// of "oldest" from the cache.
+	RemoveOldest()
+
+	// Len returns the number of items in the cache.
+	Len() int
+}
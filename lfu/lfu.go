@@ -0,0 +1,212 @@
+// Package lfu implements an LFU (least-frequently-used) cache.
+// lfu包实现了一个按访问频率淘汰的缓存
+package lfu
+
+import (
+	"container/list"
+
+	"github.com/ironzhang/groupcache/internal/cache"
+)
+
+// var _ cache.Interface = (*Cache)(nil) asserts that Cache satisfies
+// the shared cache.Interface, so code parameterized over the interface
+// can use it alongside lru.Cache and arc.Cache.
+var _ cache.Interface = (*Cache)(nil)
+
+// A Key may be any value that is comparable. See http://golang.org/ref/spec#Comparison_operators
+type Key = interface{}
+
+// Cache is an LFU cache. It is not safe for concurrent access.
+//
+// Add/Get/eviction are O(1): entries are grouped into frequency
+// buckets ordered ascending in freqList; on access an entry moves from
+// its current bucket to the next-higher one (creating it if absent)
+// and its old bucket is dropped once empty. Eviction removes the tail
+// of the lowest-frequency bucket.
+// Add/Get/淘汰均为O(1)：缓存对象按访问频率分组到频率桶中，频率桶在freqList中按频率升序排列；
+// 每次访问都会把对象从当前桶移到频率更高的桶（不存在则新建），原桶为空时则删除；
+// 淘汰时移除频率最低的桶中最久未访问的那个对象
+type Cache struct {
+	// MaxEntries is the maximum number of cache entries before
+	// an item is evicted. Zero means no limit.
+	MaxEntries int
+
+	// OnEvicted optionally specificies a callback function to be
+	// executed when an entry is purged from the cache.
+	OnEvicted func(key Key, value interface{}, reason EvictReason)
+
+	freqList *list.List               // list of *freqNode, ascending by freq
+	cache    map[interface{}]*list.Element // key -> element within some freqNode.items
+}
+
+// EvictReason indicates why an entry was removed from the cache.
+// EvictReason表示缓存对象被移除的原因
+type EvictReason int
+
+const (
+	// EvictedCapacity means the entry was evicted to make room for a
+	// new one under MaxEntries.
+	// EvictedCapacity表示因超过MaxEntries而被淘汰
+	EvictedCapacity EvictReason = iota
+
+	// EvictedRemoved means the entry was removed explicitly via Remove.
+	// EvictedRemoved表示因调用Remove而被移除
+	EvictedRemoved
+)
+
+// freqNode groups every entry that currently has the same hit count.
+// freqNode聚合了所有当前命中次数相同的缓存对象
+type freqNode struct {
+	freq  int
+	items *list.List // list of *entry
+}
+
+// 缓存对象，由键、值及其所属频率桶构成
+type entry struct {
+	key   Key
+	value interface{}
+	node  *list.Element // the freqNode element (in freqList) this entry currently belongs to
+}
+
+// New creates a new Cache.
+// If maxEntries is zero, the cache has no limit and it's assumed
+// that eviction is done by the caller.
+func New(maxEntries int) *Cache {
+	return &Cache{
+		MaxEntries: maxEntries,
+		freqList:   list.New(),
+		cache:      make(map[interface{}]*list.Element),
+	}
+}
+
+// Add adds a value to the cache, or updates it and bumps its
+// frequency if the key is already present.
+func (c *Cache) Add(key Key, value interface{}) {
+	if c.cache == nil {
+		c.cache = make(map[interface{}]*list.Element)
+		c.freqList = list.New()
+	}
+	// 键已存在，更新值并将其计入一次访问
+	if ele, ok := c.cache[key]; ok {
+		ele.Value.(*entry).value = value
+		c.touch(key, ele)
+		return
+	}
+	// 键不存在，以频率1新建一个缓存对象
+	node := c.freqNodeFor(1, nil)
+	en := &entry{key: key, value: value, node: node}
+	elem := node.Value.(*freqNode).items.PushFront(en)
+	c.cache[key] = elem
+
+	if c.MaxEntries != 0 && len(c.cache) > c.MaxEntries {
+		c.RemoveOldest()
+	}
+}
+
+// Get looks up a key's value from the cache and counts as a hit,
+// bumping the entry into the next-higher frequency bucket.
+func (c *Cache) Get(key Key) (value interface{}, ok bool) {
+	if c.cache == nil {
+		return
+	}
+	ele, hit := c.cache[key]
+	if !hit {
+		return
+	}
+	value = ele.Value.(*entry).value
+	c.touch(key, ele)
+	return value, true
+}
+
+// touch moves the entry at ele into the next-higher frequency bucket.
+// touch将ele对应的缓存对象移动到频率更高一级的桶中
+func (c *Cache) touch(key Key, ele *list.Element) {
+	en := ele.Value.(*entry)
+	oldNode := en.node
+	cur := oldNode.Value.(*freqNode)
+
+	next := c.freqNodeFor(cur.freq+1, oldNode)
+	cur.items.Remove(ele)
+	newElem := next.Value.(*freqNode).items.PushFront(en)
+	en.node = next
+	c.cache[key] = newElem
+
+	c.dropIfEmpty(oldNode, cur)
+}
+
+// freqNodeFor returns the freqNode with the given freq, inserting it
+// right after after (or at the front of freqList if after is nil) if
+// it doesn't already exist there.
+// freqNodeFor返回频率为freq的桶，如果在after之后（after为nil则在队首）不存在该频率的桶则新建一个
+func (c *Cache) freqNodeFor(freq int, after *list.Element) *list.Element {
+	if after != nil {
+		if n := after.Next(); n != nil && n.Value.(*freqNode).freq == freq {
+			return n
+		}
+		fn := &freqNode{freq: freq, items: list.New()}
+		return c.freqList.InsertAfter(fn, after)
+	}
+	if f := c.freqList.Front(); f != nil && f.Value.(*freqNode).freq == freq {
+		return f
+	}
+	fn := &freqNode{freq: freq, items: list.New()}
+	return c.freqList.PushFront(fn)
+}
+
+// dropIfEmpty removes elem, the freqList element backing node, once
+// node's item list is empty.
+func (c *Cache) dropIfEmpty(elem *list.Element, node *freqNode) {
+	if node.items.Len() != 0 {
+		return
+	}
+	c.freqList.Remove(elem)
+}
+
+// Remove removes the provided key from the cache.
+func (c *Cache) Remove(key Key) {
+	if c.cache == nil {
+		return
+	}
+	if ele, hit := c.cache[key]; hit {
+		c.removeElement(key, ele, EvictedRemoved)
+	}
+}
+
+// RemoveOldest removes the tail entry of the lowest-frequency bucket,
+// i.e. the least frequently (and, within a frequency, least recently)
+// used entry.
+func (c *Cache) RemoveOldest() {
+	if c.cache == nil {
+		return
+	}
+	front := c.freqList.Front()
+	if front == nil {
+		return
+	}
+	node := front.Value.(*freqNode)
+	back := node.items.Back()
+	if back == nil {
+		return
+	}
+	c.removeElement(back.Value.(*entry).key, back, EvictedCapacity)
+}
+
+func (c *Cache) removeElement(key Key, ele *list.Element, reason EvictReason) {
+	en := ele.Value.(*entry)
+	nodeElem := en.node
+	node := nodeElem.Value.(*freqNode)
+	node.items.Remove(ele)
+	c.dropIfEmpty(nodeElem, node)
+	delete(c.cache, key)
+	if c.OnEvicted != nil {
+		c.OnEvicted(en.key, en.value, reason)
+	}
+}
+
+// Len returns the number of items in the cache.
+func (c *Cache) Len() int {
+	if c.cache == nil {
+		return 0
+	}
+	return len(c.cache)
+}
@@ -0,0 +1,59 @@
+package lfu
+
+import "testing"
+
+// TestAddNewEntryGoesInFreq1Bucket guards against a regression where a
+// brand-new entry was anchored off the current front bucket (the branch
+// meant for touch's next-frequency bump) instead of the front of
+// freqList, which duplicated/misordered freq-1 buckets and could evict
+// a hot key instead of a cold one.
+func TestAddNewEntryGoesInFreq1Bucket(t *testing.T) {
+	c := New(2)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Get("a")
+	c.Get("a")
+
+	var evicted Key
+	c.OnEvicted = func(key Key, value interface{}, reason EvictReason) {
+		evicted = key
+	}
+	c.Add("c", 3)
+
+	if evicted != "b" {
+		t.Fatalf("RemoveOldest evicted %v, want \"b\" (the only 1-hit key)", evicted)
+	}
+}
+
+func TestGetBumpsFrequency(t *testing.T) {
+	c := New(0)
+	c.Add("a", 1)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %v, %v, want 1, true", v, ok)
+	}
+	if f := c.cache["a"].Value.(*entry).node.Value.(*freqNode).freq; f != 2 {
+		t.Fatalf("freq(a) after one Get = %d, want 2", f)
+	}
+	if _, ok := c.Get("missing"); ok {
+		t.Fatalf("Get(missing) = _, true, want false")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	c := New(0)
+	c.Add("a", 1)
+	var reason EvictReason
+	c.OnEvicted = func(key Key, value interface{}, r EvictReason) {
+		reason = r
+	}
+	c.Remove("a")
+	if reason != EvictedRemoved {
+		t.Fatalf("OnEvicted reason = %v, want EvictedRemoved", reason)
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatalf("Get(a) after Remove = _, true, want false")
+	}
+	if c.Len() != 0 {
+		t.Fatalf("Len() = %d, want 0", c.Len())
+	}
+}
@@ -0,0 +1,202 @@
+package lru
+
+import (
+	"sort"
+	"time"
+)
+
+// qpsWindow is the number of one-second buckets kept per key, giving a
+// rolling one-minute access-rate estimate.
+// qpsWindow是每个键保留的秒级桶的数量，用于估算最近一分钟的访问速率
+const qpsWindow = 60
+
+// hitStat tracks the total hit count and a rolling per-second bucket
+// ring for a single key.
+type hitStat struct {
+	hits    uint64
+	buckets [qpsWindow]uint32
+	bucket  [qpsWindow]int64 // unix second each buckets[i] belongs to
+	lastSec int64
+}
+
+// age zeroes out any bucket that has fallen out of the qpsWindow-second
+// window, lazily catching the ring buffer up to now rather than via a
+// background goroutine.
+// age惰性地将滑出窗口的桶清零，而非依赖后台goroutine
+func (s *hitStat) age(now int64) {
+	if s.lastSec == 0 {
+		s.lastSec = now
+	}
+	elapsed := now - s.lastSec
+	if elapsed <= 0 {
+		return
+	}
+	if elapsed > qpsWindow {
+		elapsed = qpsWindow
+	}
+	for i := int64(0); i < elapsed; i++ {
+		sec := s.lastSec + i + 1
+		s.buckets[sec%qpsWindow] = 0
+		s.bucket[sec%qpsWindow] = sec
+	}
+	s.lastSec = now
+}
+
+// hit records one access at second now.
+func (s *hitStat) hit(now int64) {
+	s.age(now)
+	s.hits++
+	idx := now % qpsWindow
+	if s.bucket[idx] != now {
+		s.buckets[idx] = 0
+		s.bucket[idx] = now
+	}
+	s.buckets[idx]++
+}
+
+// qps returns the average per-second rate over the trailing qpsWindow
+// seconds, after aging out any stale buckets.
+func (s *hitStat) qps(now int64) float64 {
+	s.age(now)
+	var sum uint32
+	for _, b := range s.buckets {
+		sum += b
+	}
+	return float64(sum) / float64(qpsWindow)
+}
+
+// KeyStat is a snapshot of a single key's access statistics, as
+// returned by CountingCache.TopN.
+// KeyStat是CountingCache.TopN返回的单个键的访问统计快照
+type KeyStat struct {
+	Key  Key
+	Hits uint64
+	QPS  float64
+}
+
+// CountingCache wraps a Cache and records, per key, a total hit count
+// and a rolling per-minute access rate, so a peer-aware layer can
+// decide which keys are hot enough to promote or replicate locally.
+// This mirrors the minute_qps field carried by the groupcache wire
+// protocol.
+//
+// The wrapped Cache is deliberately unexported rather than embedded:
+// CountingCache installs its own OnEvicted on it to keep stats in sync,
+// and embedding would let a caller overwrite that hook through the
+// promoted field (the documented way to observe evictions on a plain
+// Cache) and silently leak stats for evicted keys. Use OnEvict instead
+// to observe evictions on a CountingCache.
+// CountingCache在Cache基础上为每个键记录命中次数与近一分钟的访问速率，
+// 供上层据此判断哪些键足够热门、值得提升或在对等节点间复制，
+// 对应groupcache协议中携带的minute_qps字段
+//
+// 内部的Cache故意不采用匿名嵌入：CountingCache在其上安装了自己的OnEvicted以同步统计数据，
+// 若嵌入则调用方可通过被提升的字段（即在普通Cache上观察淘汰事件的文档化方式）覆盖该钩子，
+// 导致被淘汰键的统计数据永久泄漏。请改用OnEvict来观察CountingCache上的淘汰事件
+type CountingCache struct {
+	// OnEvict optionally specifies a callback function to be executed
+	// when an entry is purged from the cache, after stats bookkeeping
+	// has already been updated.
+	OnEvict func(key Key, value interface{}, reason EvictReason)
+
+	c     *Cache
+	stats map[interface{}]*hitStat
+}
+
+// NewCountingCache creates a new CountingCache.
+// If maxEntries is zero, the underlying cache has no limit and it's
+// assumed that eviction is done by the caller.
+func NewCountingCache(maxEntries int) *CountingCache {
+	cc := &CountingCache{
+		c:     New(maxEntries),
+		stats: make(map[interface{}]*hitStat),
+	}
+	cc.c.OnEvicted = func(key Key, value interface{}, reason EvictReason) {
+		delete(cc.stats, key)
+		if cc.OnEvict != nil {
+			cc.OnEvict(key, value, reason)
+		}
+	}
+	return cc
+}
+
+// Add adds a value to the cache and counts it as a hit on key.
+func (cc *CountingCache) Add(key Key, value interface{}) {
+	cc.c.Add(key, value)
+	cc.hit(key)
+}
+
+// AddWithTTL adds a value to the cache with an explicit TTL and counts
+// it as a hit on key.
+func (cc *CountingCache) AddWithTTL(key Key, value interface{}, ttl time.Duration) {
+	cc.c.AddWithTTL(key, value, ttl)
+	cc.hit(key)
+}
+
+// Get looks up a key's value from the cache, recording a hit when
+// found.
+func (cc *CountingCache) Get(key Key) (value interface{}, ok bool) {
+	value, ok = cc.c.Get(key)
+	if ok {
+		cc.hit(key)
+	}
+	return value, ok
+}
+
+// Remove removes the provided key from the cache.
+func (cc *CountingCache) Remove(key Key) {
+	cc.c.Remove(key)
+}
+
+// RemoveOldest removes the entry chosen by the underlying Cache's
+// EvictionPolicy from the cache.
+func (cc *CountingCache) RemoveOldest() {
+	cc.c.RemoveOldest()
+}
+
+// Len returns the number of items in the cache.
+func (cc *CountingCache) Len() int {
+	return cc.c.Len()
+}
+
+func (cc *CountingCache) hit(key Key) {
+	st, ok := cc.stats[key]
+	if !ok {
+		st = &hitStat{}
+		cc.stats[key] = st
+	}
+	st.hit(time.Now().Unix())
+}
+
+// Stats returns the total hit count and the rolling per-minute access
+// rate for key. ok is false if key has never been added or fetched
+// (or has since been evicted).
+func (cc *CountingCache) Stats(key Key) (hits uint64, qps float64, ok bool) {
+	st, found := cc.stats[key]
+	if !found {
+		return 0, 0, false
+	}
+	now := time.Now().Unix()
+	return st.hits, st.qps(now), true
+}
+
+// TopN returns the n hottest keys, ordered by descending QPS (ties
+// broken by descending total hit count). A negative n means no limit,
+// matching the convention of strings.SplitN and similar stdlib APIs.
+func (cc *CountingCache) TopN(n int) []KeyStat {
+	now := time.Now().Unix()
+	all := make([]KeyStat, 0, len(cc.stats))
+	for key, st := range cc.stats {
+		all = append(all, KeyStat{Key: key, Hits: st.hits, QPS: st.qps(now)})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].QPS != all[j].QPS {
+			return all[i].QPS > all[j].QPS
+		}
+		return all[i].Hits > all[j].Hits
+	})
+	if n >= 0 && n < len(all) {
+		all = all[:n]
+	}
+	return all
+}
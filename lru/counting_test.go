@@ -0,0 +1,88 @@
+package lru
+
+import "testing"
+
+func TestCountingCacheStats(t *testing.T) {
+	cc := NewCountingCache(0)
+	cc.Add("a", 1)
+	cc.Get("a")
+	cc.Get("a")
+
+	hits, qps, ok := cc.Stats("a")
+	if !ok {
+		t.Fatalf("Stats(a) ok = false, want true")
+	}
+	if hits != 3 { // 1 Add + 2 Get
+		t.Fatalf("hits = %d, want 3", hits)
+	}
+	if qps <= 0 {
+		t.Fatalf("qps = %v, want > 0", qps)
+	}
+
+	if _, _, ok := cc.Stats("missing"); ok {
+		t.Fatalf("Stats(missing) ok = true, want false")
+	}
+}
+
+func TestCountingCacheTopN(t *testing.T) {
+	cc := NewCountingCache(0)
+	cc.Add("a", 1)
+	cc.Add("b", 2)
+	cc.Get("b")
+	cc.Get("b")
+	cc.Add("c", 3)
+
+	top := cc.TopN(2)
+	if len(top) != 2 {
+		t.Fatalf("len(TopN(2)) = %d, want 2", len(top))
+	}
+	if top[0].Key != "b" {
+		t.Fatalf("TopN(2)[0].Key = %v, want \"b\" (most hits)", top[0].Key)
+	}
+
+	if got := len(cc.TopN(0)); got != 0 {
+		t.Fatalf("len(TopN(0)) = %d, want 0", got)
+	}
+	if got := len(cc.TopN(-1)); got != 3 {
+		t.Fatalf("len(TopN(-1)) = %d, want 3 (negative n means no limit)", got)
+	}
+}
+
+func TestCountingCacheTopNTieBreaksOnHits(t *testing.T) {
+	cc := NewCountingCache(0)
+	now := int64(1000)
+	cc.stats["a"] = &hitStat{hits: 5}
+	cc.stats["a"].age(now)
+	cc.stats["b"] = &hitStat{hits: 10}
+	cc.stats["b"].age(now)
+
+	top := cc.TopN(-1)
+	if len(top) != 2 || top[0].Key != "b" {
+		t.Fatalf("TopN() = %v, want \"b\" first on tied (zero) QPS but higher hits", top)
+	}
+}
+
+// TestCountingCacheEvictionCleansUpStats guards against a regression
+// where exposing a mutable, promoted OnEvicted let a caller clobber the
+// internal hook NewCountingCache installs to delete stats on eviction,
+// leaking a stats entry for a key no longer in the cache.
+func TestCountingCacheEvictionCleansUpStats(t *testing.T) {
+	cc := NewCountingCache(1)
+	cc.Add("a", 1)
+
+	var got Key
+	cc.OnEvict = func(key Key, value interface{}, reason EvictReason) {
+		got = key
+	}
+	cc.Add("b", 2) // evicts "a"
+
+	if got != "a" {
+		t.Fatalf("OnEvict key = %v, want \"a\"", got)
+	}
+	if _, _, ok := cc.Stats("a"); ok {
+		t.Fatalf("Stats(a) ok = true after eviction, want false")
+	}
+	if cc.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", cc.Len())
+	}
+}
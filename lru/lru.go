@@ -18,7 +18,66 @@ limitations under the License.
 // lur缓存组件
 package lru
 
-import "container/list"
+import (
+	"container/list"
+	"time"
+
+	"github.com/ironzhang/groupcache/internal/cache"
+)
+
+// var _ cache.Interface = (*Cache)(nil) asserts that Cache satisfies
+// the shared cache.Interface, so code parameterized over the interface
+// can use it alongside lfu.Cache and arc.Cache.
+var _ cache.Interface = (*Cache)(nil)
+
+// EvictionPolicy selects the strategy used to pick a victim when the
+// cache is over MaxEntries.
+// EvictionPolicy用于选择缓存对象数超过上限时的淘汰策略
+type EvictionPolicy int
+
+const (
+	// PolicyLRU evicts the least recently used entry (the current,
+	// default behavior).
+	// PolicyLRU淘汰最久未使用的缓存对象（默认行为）
+	PolicyLRU EvictionPolicy = iota
+
+	// PolicyLRUApprox samples SampleSize random entries from the cache
+	// and evicts the one with the oldest access timestamp, trading
+	// strict LRU ordering for O(1) eviction regardless of cache size.
+	// PolicyLRUApprox从缓存中随机采样SampleSize个对象，淘汰其中最久未访问的那个
+	PolicyLRUApprox
+
+	// PolicyTTL samples SampleSize random entries and evicts the one
+	// with the nearest expiration time. Entries with no expiration are
+	// treated as expiring last.
+	// PolicyTTL从缓存中随机采样SampleSize个对象，淘汰其中最快过期的那个
+	PolicyTTL
+)
+
+// EvictReason indicates why an entry was removed from the cache.
+// EvictReason表示缓存对象被移除的原因
+type EvictReason int
+
+const (
+	// EvictedCapacity means the entry was evicted to make room for a
+	// new one under MaxEntries (via PolicyLRU, PolicyLRUApprox or
+	// PolicyTTL).
+	// EvictedCapacity表示因超过MaxEntries而被淘汰
+	EvictedCapacity EvictReason = iota
+
+	// EvictedExpired means the entry's TTL had elapsed.
+	// EvictedExpired表示因TTL过期而被移除
+	EvictedExpired
+
+	// EvictedRemoved means the entry was removed explicitly via Remove.
+	// EvictedRemoved表示因调用Remove而被移除
+	EvictedRemoved
+)
+
+// DefaultSampleSize is the number of entries sampled by PolicyLRUApprox
+// and PolicyTTL when SampleSize is left at zero.
+// DefaultSampleSize是SampleSize未设置时，PolicyLRUApprox与PolicyTTL默认的采样数量
+const DefaultSampleSize = 5
 
 // Cache is an LRU cache. It is not safe for concurrent access.
 type Cache struct {
@@ -27,22 +86,57 @@ type Cache struct {
 	// 最大缓存对象数
 	MaxEntries int
 
+	// DefaultTTL is the expiration applied to entries added via Add
+	// when no explicit TTL is given. Zero means entries never expire
+	// on their own.
+	// DefaultTTL是通过Add添加的缓存对象的默认过期时间，为0表示永不过期
+	DefaultTTL time.Duration
+
+	// EvictionPolicy selects how a victim is chosen when the cache is
+	// over MaxEntries. The zero value is PolicyLRU.
+	// EvictionPolicy选择超出MaxEntries时的淘汰策略，零值为PolicyLRU
+	EvictionPolicy EvictionPolicy
+
+	// SampleSize is the number of entries sampled by PolicyLRUApprox and
+	// PolicyTTL. Zero means DefaultSampleSize.
+	// SampleSize是PolicyLRUApprox与PolicyTTL的采样数量，为0则使用DefaultSampleSize
+	SampleSize int
+
 	// OnEvicted optionally specificies a callback function to be
 	// executed when an entry is purged from the cache.
 	// 移除缓存对象时回调
-	OnEvicted func(key Key, value interface{})
+	OnEvicted func(key Key, value interface{}, reason EvictReason)
 
 	ll    *list.List
 	cache map[interface{}]*list.Element
 }
 
 // A Key may be any value that is comparable. See http://golang.org/ref/spec#Comparison_operators
-type Key interface{}
+//
+// Key is a type alias (rather than a defined type) so that Cache can be
+// used as an internal/cache.Interface alongside the lfu and arc
+// implementations.
+// Key是一个类型别名而非独立定义的类型，使得Cache可以和lfu、arc的实现一样满足internal/cache.Interface
+type Key = interface{}
 
 // 缓存对象，由键与值构成
 type entry struct {
 	key   Key
 	value interface{}
+
+	// lastAccess records the last time this entry was added to or
+	// fetched from the cache, used by PolicyLRUApprox.
+	// lastAccess记录该对象最后一次被访问的时间，供PolicyLRUApprox使用
+	lastAccess time.Time
+
+	// expireAt is the time at which this entry becomes stale. The zero
+	// value means the entry never expires.
+	// expireAt是该对象的过期时间，零值表示永不过期
+	expireAt time.Time
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && !e.expireAt.After(now)
 }
 
 // New creates a new Cache.
@@ -58,38 +152,62 @@ func New(maxEntries int) *Cache {
 	}
 }
 
-// Add adds a value to the cache.
-// 添加一个值到缓存
+// Add adds a value to the cache, expiring it after DefaultTTL (which is
+// no expiration if DefaultTTL is zero).
+// 添加一个值到缓存，过期时间为DefaultTTL
 func (c *Cache) Add(key Key, value interface{}) {
+	c.AddWithTTL(key, value, c.DefaultTTL)
+}
+
+// AddWithTTL adds a value to the cache that expires after ttl. A zero
+// ttl means the entry never expires on its own.
+// 添加一个值到缓存，并指定其过期时间，ttl为0表示永不过期
+func (c *Cache) AddWithTTL(key Key, value interface{}, ttl time.Duration) {
 	// 支持延迟初始化
 	if c.cache == nil {
 		c.cache = make(map[interface{}]*list.Element)
 		c.ll = list.New()
 	}
+	now := time.Now()
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = now.Add(ttl)
+	}
 	// 如果键在缓存中已存在，则将该缓存对象移到缓存队列头部，并更新其值
 	if ee, ok := c.cache[key]; ok {
 		c.ll.MoveToFront(ee)
-		ee.Value.(*entry).value = value
+		en := ee.Value.(*entry)
+		en.value = value
+		en.lastAccess = now
+		en.expireAt = expireAt
 		return
 	}
 	// 不存在，则构建一个缓存对象并压入缓存队列头部
-	ele := c.ll.PushFront(&entry{key, value})
+	ele := c.ll.PushFront(&entry{key: key, value: value, lastAccess: now, expireAt: expireAt})
 	c.cache[key] = ele // 建立键与缓存对象的映射
-	// 如果缓存对象数超过上限，则将最长时间未使用的缓存对象移除
+	// 如果缓存对象数超过上限，则按淘汰策略移除一个缓存对象
 	if c.MaxEntries != 0 && c.ll.Len() > c.MaxEntries {
-		c.RemoveOldest()
+		c.evictOne()
 	}
 }
 
-// Get looks up a key's value from the cache.
+// Get looks up a key's value from the cache. An expired entry is
+// treated as a miss and evicted.
+// Get根据键查找缓存对象，已过期的对象视为未命中并被移除
 func (c *Cache) Get(key Key) (value interface{}, ok bool) {
 	if c.cache == nil {
 		return
 	}
 	// 根据键查找缓存对象
 	if ele, hit := c.cache[key]; hit {
+		en := ele.Value.(*entry)
+		if en.expired(time.Now()) {
+			c.removeElement(ele, EvictedExpired)
+			return
+		}
 		c.ll.MoveToFront(ele) // 将缓存对象移到缓存队列头部
-		return ele.Value.(*entry).value, true
+		en.lastAccess = time.Now()
+		return en.value, true
 	}
 	return
 }
@@ -101,28 +219,126 @@ func (c *Cache) Remove(key Key) {
 		return
 	}
 	if ele, hit := c.cache[key]; hit {
-		c.removeElement(ele) // 移除缓存对象
+		c.removeElement(ele, EvictedRemoved) // 移除缓存对象
 	}
 }
 
-// RemoveOldest removes the oldest item from the cache.
-// 移除最久未使用的缓存对象
+// RemoveOldest removes the entry chosen by EvictionPolicy from the cache.
+// 根据EvictionPolicy移除一个缓存对象
 func (c *Cache) RemoveOldest() {
 	if c.cache == nil {
 		return
 	}
-	ele := c.ll.Back() // 取得缓存队列某位的缓存对象
-	if ele != nil {
-		c.removeElement(ele) // 移除该对象
+	c.evictOne()
+}
+
+// RemoveExpired sweeps the cache and evicts every entry whose TTL has
+// elapsed. Callers that want to bound memory proactively rather than
+// waiting for Get/Add to notice can run this periodically.
+// RemoveExpired遍历缓存，移除所有已过期的对象，供需要主动清理的调用方周期性调用
+func (c *Cache) RemoveExpired() {
+	if c.cache == nil {
+		return
+	}
+	now := time.Now()
+	var expired []*list.Element
+	for e := c.ll.Back(); e != nil; e = e.Prev() {
+		if e.Value.(*entry).expired(now) {
+			expired = append(expired, e)
+		}
+	}
+	for _, e := range expired {
+		c.removeElement(e, EvictedExpired)
+	}
+}
+
+// evictOne picks a victim according to EvictionPolicy and removes it.
+// evictOne按照EvictionPolicy选择一个淘汰对象并将其移除
+func (c *Cache) evictOne() {
+	switch c.EvictionPolicy {
+	case PolicyLRUApprox:
+		if ele := c.sampleOldestAccess(); ele != nil {
+			c.removeElement(ele, EvictedCapacity)
+		}
+	case PolicyTTL:
+		if ele := c.sampleNearestExpiry(); ele != nil {
+			c.removeElement(ele, EvictedCapacity)
+		}
+	default:
+		ele := c.ll.Back() // 取得缓存队列末位的缓存对象
+		if ele != nil {
+			c.removeElement(ele, EvictedCapacity) // 移除该对象
+		}
+	}
+}
+
+// sampleSize returns the configured SampleSize, or DefaultSampleSize if
+// unset.
+func (c *Cache) sampleSize() int {
+	if c.SampleSize > 0 {
+		return c.SampleSize
+	}
+	return DefaultSampleSize
+}
+
+// sampleOldestAccess samples SampleSize random entries from the map and
+// returns the one with the oldest lastAccess. Go's map iteration order
+// is randomized, so ranging and stopping early is itself the sample.
+// sampleOldestAccess从map中随机采样SampleSize个对象，返回其中lastAccess最旧的那个
+func (c *Cache) sampleOldestAccess() *list.Element {
+	var oldest *list.Element
+	n := c.sampleSize()
+	for _, ele := range c.cache {
+		en := ele.Value.(*entry)
+		if oldest == nil || en.lastAccess.Before(oldest.Value.(*entry).lastAccess) {
+			oldest = ele
+		}
+		n--
+		if n <= 0 {
+			break
+		}
+	}
+	return oldest
+}
+
+// sampleNearestExpiry samples SampleSize random entries from the map and
+// returns the one expiring soonest. Entries with no expiration are
+// treated as expiring last.
+// sampleNearestExpiry从map中随机采样SampleSize个对象，返回其中最快过期的那个
+func (c *Cache) sampleNearestExpiry() *list.Element {
+	var nearest *list.Element
+	n := c.sampleSize()
+	for _, ele := range c.cache {
+		en := ele.Value.(*entry)
+		if nearest == nil || expiresBefore(en, nearest.Value.(*entry)) {
+			nearest = ele
+		}
+		n--
+		if n <= 0 {
+			break
+		}
+	}
+	return nearest
+}
+
+// expiresBefore reports whether a expires before b, treating a zero
+// expireAt (no expiration) as expiring last.
+func expiresBefore(a, b *entry) bool {
+	if a.expireAt.IsZero() {
+		return false
+	}
+	if b.expireAt.IsZero() {
+		return true
 	}
+	return a.expireAt.Before(b.expireAt)
 }
 
-func (c *Cache) removeElement(e *list.Element) {
+func (c *Cache) removeElement(e *list.Element, reason EvictReason) {
 	c.ll.Remove(e) // 从缓存列表中移除该元素
 	kv := e.Value.(*entry)
 	delete(c.cache, kv.key) // 从缓存映射表中移除该缓存对象
 	if c.OnEvicted != nil {
-		c.OnEvicted(kv.key, kv.value) // 移除时的回调处理
+		c.OnEvicted(kv.key, kv.value, reason) // 移除时的回调处理
 	}
 }
 
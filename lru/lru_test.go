@@ -0,0 +1,65 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddWithTTLExpires(t *testing.T) {
+	c := New(0)
+	c.AddWithTTL("a", 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	var evicted Key
+	var reason EvictReason
+	c.OnEvicted = func(key Key, value interface{}, r EvictReason) {
+		evicted, reason = key, r
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatalf("Get(a) = _, true after TTL elapsed, want false")
+	}
+	if evicted != "a" || reason != EvictedExpired {
+		t.Fatalf("OnEvicted(%v, _, %v), want (a, EvictedExpired)", evicted, reason)
+	}
+}
+
+func TestRemoveExpired(t *testing.T) {
+	c := New(0)
+	c.AddWithTTL("a", 1, time.Millisecond)
+	c.Add("b", 2) // no TTL
+	time.Sleep(5 * time.Millisecond)
+
+	c.RemoveExpired()
+	if c.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", c.Len())
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Fatalf("Get(b) = _, false, want true")
+	}
+}
+
+func TestPolicyLRUApproxStaysWithinCapacity(t *testing.T) {
+	c := &Cache{MaxEntries: 2, EvictionPolicy: PolicyLRUApprox}
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Add("c", 3)
+	if c.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", c.Len())
+	}
+}
+
+func TestPolicyTTLEvictsNearestExpiry(t *testing.T) {
+	c := &Cache{MaxEntries: 2, EvictionPolicy: PolicyTTL}
+	c.AddWithTTL("soon", 1, time.Millisecond)
+	c.AddWithTTL("later", 2, time.Hour)
+
+	var evicted Key
+	c.OnEvicted = func(key Key, value interface{}, reason EvictReason) {
+		evicted = key
+	}
+	c.AddWithTTL("c", 3, time.Hour)
+
+	if evicted != "soon" {
+		t.Fatalf("evicted = %v, want \"soon\" (nearest expiry)", evicted)
+	}
+}
@@ -0,0 +1,213 @@
+package lru
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// Hasher maps a Key to a uint64 used to pick a shard in a ShardedCache.
+type Hasher func(key Key) uint64
+
+// DefaultHasher hashes a Key with xxHash64, taking a fast path for
+// string, []byte and the common integer types (hashing their bytes
+// directly) and falling back to hashing fmt.Sprint(key) for anything
+// else.
+// DefaultHasher使用xxHash64对Key做哈希，对string、[]byte以及常见整型直接哈希其字节以走快速路径，
+// 其余类型则退化为对fmt.Sprint(key)的结果做哈希
+func DefaultHasher(key Key) uint64 {
+	switch k := key.(type) {
+	case string:
+		return xxhash64([]byte(k), 0)
+	case []byte:
+		return xxhash64(k, 0)
+	case int:
+		return hashUint64(uint64(k))
+	case int32:
+		return hashUint64(uint64(k))
+	case int64:
+		return hashUint64(uint64(k))
+	case uint:
+		return hashUint64(uint64(k))
+	case uint32:
+		return hashUint64(uint64(k))
+	case uint64:
+		return hashUint64(k)
+	default:
+		return xxhash64([]byte(fmt.Sprint(key)), 0)
+	}
+}
+
+func hashUint64(v uint64) uint64 {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return xxhash64(buf[:], 0)
+}
+
+// shard is one of a ShardedCache's independent, mutex-guarded slices
+// of the keyspace.
+type shard struct {
+	mu sync.Mutex
+	c  *Cache
+}
+
+// ShardedCache fans an LRU cache out across a power-of-two number of
+// independently-locked shards, so concurrent callers hashing to
+// different shards don't contend on a single mutex the way a caller
+// wrapping a plain Cache would.
+// ShardedCache将LRU缓存分散到若干（2的幂次方）个各自加锁的分片中，
+// 使哈希到不同分片的并发调用不必像包一个普通Cache那样争用同一把锁
+type ShardedCache struct {
+	// OnEvicted optionally specifies a callback function to be
+	// executed when an entry is purged from any shard.
+	OnEvicted func(key Key, value interface{}, reason EvictReason)
+
+	// Hasher selects the shard for a key. Changing it after the cache
+	// has entries in it is not supported.
+	Hasher Hasher
+
+	shards []*shard
+}
+
+// NewSharded creates a ShardedCache with shardCount shards (rounded up
+// to the next power of two, then halved while it exceeds a non-zero
+// maxEntries so every shard has a capacity of at least one) and a total
+// capacity of maxEntries, split as evenly as possible across shards
+// (the first maxEntries%n shards get one extra entry) so the sum of
+// per-shard capacities never exceeds maxEntries. A maxEntries of zero
+// means no limit.
+// NewSharded创建一个有shardCount个分片（向上取整为2的幂次方，若超过非零的maxEntries则减半直至不超过，
+// 使每个分片容量至少为1）的ShardedCache，
+// 总容量maxEntries会被尽量平均地分配到各个分片（前maxEntries%n个分片多分到一个），
+// 使各分片容量之和不超过maxEntries；maxEntries为0表示没有上限
+func NewSharded(shardCount, maxEntries int) *ShardedCache {
+	n := nextPowerOfTwo(shardCount)
+	for maxEntries > 0 && n > maxEntries {
+		n >>= 1
+	}
+	base, extra := 0, 0
+	if maxEntries != 0 {
+		base = maxEntries / n
+		extra = maxEntries % n
+	}
+
+	sc := &ShardedCache{
+		Hasher: DefaultHasher,
+		shards: make([]*shard, n),
+	}
+	for i := range sc.shards {
+		perShard := base
+		if i < extra {
+			perShard++
+		}
+		s := &shard{c: New(perShard)}
+		s.c.OnEvicted = func(key Key, value interface{}, reason EvictReason) {
+			if sc.OnEvicted != nil {
+				sc.OnEvicted(key, value, reason)
+			}
+		}
+		sc.shards[i] = s
+	}
+	return sc
+}
+
+func nextPowerOfTwo(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+func (sc *ShardedCache) shardFor(key Key) *shard {
+	h := sc.Hasher(key)
+	return sc.shards[h&uint64(len(sc.shards)-1)]
+}
+
+// Add adds a value to the cache.
+func (sc *ShardedCache) Add(key Key, value interface{}) {
+	s := sc.shardFor(key)
+	s.mu.Lock()
+	s.c.Add(key, value)
+	s.mu.Unlock()
+}
+
+// Get looks up a key's value from the cache.
+func (sc *ShardedCache) Get(key Key) (value interface{}, ok bool) {
+	s := sc.shardFor(key)
+	s.mu.Lock()
+	value, ok = s.c.Get(key)
+	s.mu.Unlock()
+	return value, ok
+}
+
+// Remove removes the provided key from the cache.
+func (sc *ShardedCache) Remove(key Key) {
+	s := sc.shardFor(key)
+	s.mu.Lock()
+	s.c.Remove(key)
+	s.mu.Unlock()
+}
+
+// Len returns the number of items in the cache, across all shards.
+func (sc *ShardedCache) Len() int {
+	n := 0
+	for _, s := range sc.shards {
+		s.mu.Lock()
+		n += s.c.Len()
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Range calls fn for each key/value pair in the cache, one shard at a
+// time, stopping early if fn returns false. Range makes no guarantees
+// about ordering, and a key added or removed during the Range may or
+// may not be visited.
+// Range逐个分片地对缓存中的每个键值对调用fn，fn返回false则提前停止；
+// Range不保证遍历顺序，遍历期间新增或移除的键可能被访问到也可能不会
+func (sc *ShardedCache) Range(fn func(key Key, value interface{}) bool) {
+	for _, s := range sc.shards {
+		if !sc.rangeShard(s, fn) {
+			return
+		}
+	}
+}
+
+func (sc *ShardedCache) rangeShard(s *shard, fn func(key Key, value interface{}) bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.c.ll == nil {
+		return true
+	}
+	for e := s.c.ll.Front(); e != nil; e = e.Next() {
+		en := e.Value.(*entry)
+		if !fn(en.key, en.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// ShardStat is a per-shard snapshot returned by ShardedCache.Stats.
+type ShardStat struct {
+	Len int
+}
+
+// Stats returns a length snapshot of every shard, in shard order. It's
+// meant for observability (checking for a lopsided Hasher), not for
+// anything load-bearing.
+// Stats返回各分片的长度快照（按分片顺序），用于观测（比如检查Hasher是否导致分片不均），
+// 而非用于任何业务逻辑
+func (sc *ShardedCache) Stats() []ShardStat {
+	stats := make([]ShardStat, len(sc.shards))
+	for i, s := range sc.shards {
+		s.mu.Lock()
+		stats[i] = ShardStat{Len: s.c.Len()}
+		s.mu.Unlock()
+	}
+	return stats
+}
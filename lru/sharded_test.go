@@ -0,0 +1,79 @@
+package lru
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// TestNewShardedCapacityNeverExceedsMaxEntries guards against the
+// per-shard capacity being rounded up on every shard, which overshoots
+// the configured MaxEntries.
+func TestNewShardedCapacityNeverExceedsMaxEntries(t *testing.T) {
+	const shards, maxEntries = 8, 10
+	sc := NewSharded(shards, maxEntries)
+
+	total := 0
+	for _, s := range sc.shards {
+		total += s.c.MaxEntries
+	}
+	if total > maxEntries {
+		t.Fatalf("sum of per-shard MaxEntries = %d, want <= %d", total, maxEntries)
+	}
+}
+
+func TestShardedAddGet(t *testing.T) {
+	sc := NewSharded(4, 0)
+	sc.Add("a", 1)
+	if v, ok := sc.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %v, %v, want 1, true", v, ok)
+	}
+	sc.Remove("a")
+	if _, ok := sc.Get("a"); ok {
+		t.Fatalf("Get(a) after Remove = _, true, want false")
+	}
+}
+
+// BenchmarkSingleMutex and BenchmarkSharded compare a plain Cache behind
+// one sync.Mutex against ShardedCache's fanned-out locking, at a
+// b.SetParallelism of 1/8/64, i.e. 1, 8 and 64 goroutines per GOMAXPROCS
+// (exactly 1/8/64 goroutines only when GOMAXPROCS is 1).
+func BenchmarkSingleMutex(b *testing.B) {
+	for _, goroutines := range []int{1, 8, 64} {
+		b.Run(fmt.Sprintf("parallelism=%d", goroutines), func(b *testing.B) {
+			var mu sync.Mutex
+			c := New(1 << 16)
+			b.SetParallelism(goroutines)
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					key := strconv.Itoa(i % 4096)
+					mu.Lock()
+					c.Add(key, i)
+					c.Get(key)
+					mu.Unlock()
+					i++
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkSharded(b *testing.B) {
+	for _, goroutines := range []int{1, 8, 64} {
+		b.Run(fmt.Sprintf("parallelism=%d", goroutines), func(b *testing.B) {
+			sc := NewSharded(64, 1<<16)
+			b.SetParallelism(goroutines)
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					key := strconv.Itoa(i % 4096)
+					sc.Add(key, i)
+					sc.Get(key)
+					i++
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package lru
+
+import "encoding/binary"
+
+// This is a plain reimplementation of the xxHash64 algorithm
+// (https://github.com/Cyan4973/xxHash), used by ShardedCache's default
+// Hasher. It exists here, rather than as a dependency, because the
+// dependency is tiny and this avoids pulling in a module for one
+// function.
+// 这是xxHash64算法的一个简单实现，被ShardedCache的默认Hasher使用。
+// 之所以内置而非引入依赖，是因为所需功能很小，没必要为一个函数引入一个模块
+
+const (
+	xxhPrime1 uint64 = 11400714785074694791
+	xxhPrime2 uint64 = 14029467366897019727
+	xxhPrime3 uint64 = 1609587929392839161
+	xxhPrime4 uint64 = 9650029242287828579
+	xxhPrime5 uint64 = 2870177450012600261
+)
+
+// xxhash64 computes the 64-bit xxHash of b with the given seed.
+func xxhash64(b []byte, seed uint64) uint64 {
+	n := len(b)
+	var h64 uint64
+
+	if n >= 32 {
+		v1 := seed + xxhPrime1 + xxhPrime2
+		v2 := seed + xxhPrime2
+		v3 := seed
+		v4 := seed - xxhPrime1
+		for len(b) >= 32 {
+			v1 = xxhRound(v1, binary.LittleEndian.Uint64(b[0:8]))
+			v2 = xxhRound(v2, binary.LittleEndian.Uint64(b[8:16]))
+			v3 = xxhRound(v3, binary.LittleEndian.Uint64(b[16:24]))
+			v4 = xxhRound(v4, binary.LittleEndian.Uint64(b[24:32]))
+			b = b[32:]
+		}
+		h64 = xxhRotl64(v1, 1) + xxhRotl64(v2, 7) + xxhRotl64(v3, 12) + xxhRotl64(v4, 18)
+		h64 = xxhMergeRound(h64, v1)
+		h64 = xxhMergeRound(h64, v2)
+		h64 = xxhMergeRound(h64, v3)
+		h64 = xxhMergeRound(h64, v4)
+	} else {
+		h64 = seed + xxhPrime5
+	}
+
+	h64 += uint64(n)
+
+	for len(b) >= 8 {
+		k1 := xxhRound(0, binary.LittleEndian.Uint64(b[0:8]))
+		h64 ^= k1
+		h64 = xxhRotl64(h64, 27)*xxhPrime1 + xxhPrime4
+		b = b[8:]
+	}
+	if len(b) >= 4 {
+		h64 ^= uint64(binary.LittleEndian.Uint32(b[0:4])) * xxhPrime1
+		h64 = xxhRotl64(h64, 23)*xxhPrime2 + xxhPrime3
+		b = b[4:]
+	}
+	for len(b) > 0 {
+		h64 ^= uint64(b[0]) * xxhPrime5
+		h64 = xxhRotl64(h64, 11) * xxhPrime1
+		b = b[1:]
+	}
+
+	h64 ^= h64 >> 33
+	h64 *= xxhPrime2
+	h64 ^= h64 >> 29
+	h64 *= xxhPrime3
+	h64 ^= h64 >> 32
+	return h64
+}
+
+func xxhRound(acc, input uint64) uint64 {
+	acc += input * xxhPrime2
+	acc = xxhRotl64(acc, 31)
+	acc *= xxhPrime1
+	return acc
+}
+
+func xxhMergeRound(acc, val uint64) uint64 {
+	val = xxhRound(0, val)
+	acc ^= val
+	acc = acc*xxhPrime1 + xxhPrime4
+	return acc
+}
+
+func xxhRotl64(x uint64, r uint) uint64 {
+	return (x << r) | (x >> (64 - r))
+}
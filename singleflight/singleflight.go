@@ -19,13 +19,65 @@ limitations under the License.
 // singleflight包提供了一种抑制函数重复调用的机制
 package singleflight
 
-import "sync"
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
 
-// call is an in-flight or completed Do call
+// errGoexit indicates the runtime.Goexit was called in
+// the user given function.
+var errGoexit = fmt.Errorf("runtime.Goexit was called")
+
+// A panicError is an arbitrary value recovered from a panic, with the
+// stack trace captured at the time of the panic, so it can be
+// re-raised in every waiting goroutine.
+// panicError保存了从panic中恢复的任意值以及发生时的调用栈，便于在所有等待的goroutine中重新抛出
+type panicError struct {
+	value interface{}
+	stack []byte
+}
+
+// Error implements error interface.
+func (p *panicError) Error() string {
+	return fmt.Sprintf("%v\n\n%s", p.value, p.stack)
+}
+
+func newPanicError(v interface{}) error {
+	stack := debug.Stack()
+
+	// The first line of the stack trace is of the form "goroutine N [status]:"
+	// but by the time the panic reaches Do the goroutine may no longer exist
+	// and its status will have changed. Trim out the misleading line.
+	if line := bytes.IndexByte(stack, '\n'); line >= 0 {
+		stack = stack[line+1:]
+	}
+	return &panicError{value: v, stack: stack}
+}
+
+// call is an in-flight or completed Do/DoChan call
 type call struct {
-	wg  sync.WaitGroup
+	wg sync.WaitGroup
+
+	// These fields are written once before the WaitGroup is done
+	// and are only read after the WaitGroup is done.
 	val interface{}
 	err error
+
+	// forgotten indicates whether Forget was called with this call's
+	// key while the call was still in flight.
+	// forgotten表示在该call仍在执行期间是否被Forget移除过
+	forgotten bool
+
+	// dups is the number of callers sharing this call, beyond the one
+	// that created it.
+	dups int
+	// chans holds the result channels for every DoChan caller waiting
+	// on this call.
+	chans []chan<- Result
 }
 
 // Group represents a class of work and forms a namespace in which
@@ -35,6 +87,14 @@ type Group struct {
 	m  map[string]*call // lazily initialized
 }
 
+// Result holds the results of Do, so they can be passed
+// on a channel.
+type Result struct {
+	Val    interface{}
+	Err    error
+	Shared bool
+}
+
 // Do executes and returns the results of the given function, making
 // sure that only one execution is in-flight for a given key at a
 // time. If a duplicate comes in, the duplicate caller waits for the
@@ -48,15 +108,22 @@ type Group struct {
 // goroutine2
 //   val, err := g.Do("iron", LoadUserFromDB)
 // 只有一个goroutine会真正调用LoadUserFromDB，另一个会等待真正调用的结果，这也是这个包叫singleflight的原因吧 ^_^
-func (g *Group) Do(key string, fn func() (interface{}, error)) (interface{}, error) {
+func (g *Group) Do(key string, fn func() (interface{}, error)) (v interface{}, err error) {
 	g.mu.Lock()
 	if g.m == nil {
 		g.m = make(map[string]*call)
 	}
 	// 如果函数已在调用中，则无需执行，等待之前调用的函数执行完成即可
 	if c, ok := g.m[key]; ok {
+		c.dups++
 		g.mu.Unlock()
 		c.wg.Wait() // 等待函数执行完毕
+
+		if e, ok := c.err.(*panicError); ok {
+			panic(e) // 前调函数发生了panic，在这里重新抛出
+		} else if c.err == errGoexit {
+			runtime.Goexit()
+		}
 		return c.val, c.err // 返回函数的执行结果
 	}
 	// 没有在调用中，则新建一个call
@@ -65,15 +132,133 @@ func (g *Group) Do(key string, fn func() (interface{}, error)) (interface{}, err
 	g.m[key] = c
 	g.mu.Unlock()
 
-	// 执行函数，并将结果保存在call中
-	c.val, c.err = fn()
-	c.wg.Done() // 函数执行完毕，解锁其他在等待中的执行流
+	g.doCall(c, key, fn)
+	return c.val, c.err
+}
 
-	// 函数执行完毕，移除call
+// DoChan is like Do but returns a channel that will receive the
+// results when they are ready. The channel is closed once the result
+// has been sent, so callers can range over it or just receive once.
+// DoChan与Do类似，但返回一个channel，调用完成后结果会发送到该channel并关闭
+func (g *Group) DoChan(key string, fn func() (interface{}, error)) <-chan Result {
+	ch := make(chan Result, 1)
 	g.mu.Lock()
-	delete(g.m, key)
+	if g.m == nil {
+		g.m = make(map[string]*call)
+	}
+	if c, ok := g.m[key]; ok {
+		c.dups++
+		c.chans = append(c.chans, ch)
+		g.mu.Unlock()
+		return ch
+	}
+	c := &call{chans: []chan<- Result{ch}}
+	c.wg.Add(1)
+	g.m[key] = c
 	g.mu.Unlock()
 
-	// 返回执行结果
-	return c.val, c.err
+	go g.doCall(c, key, fn)
+
+	return ch
+}
+
+// DoContext is like Do, but the caller unblocks as soon as ctx is
+// done, returning ctx.Err() instead of waiting for fn to finish. The
+// underlying call keeps running to completion for any other callers
+// still waiting on it; ctx is never used to cancel fn itself.
+// DoContext与Do类似，但当ctx被取消或超时时会立即返回ctx.Err()而不再等待fn执行完毕；
+// fn本身不会被ctx取消，仍会继续运行以便服务其他仍在等待的调用方
+func (g *Group) DoContext(ctx context.Context, key string, fn func() (interface{}, error)) (v interface{}, err error) {
+	ch := g.DoChan(key, fn)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		if e, ok := r.Err.(*panicError); ok {
+			panic(e)
+		} else if r.Err == errGoexit {
+			runtime.Goexit()
+		}
+		return r.Val, r.Err
+	}
+}
+
+// doCall handles the single call for a key.
+func (g *Group) doCall(c *call, key string, fn func() (interface{}, error)) {
+	normalReturn := false
+	recovered := false
+
+	// use double-defer to distinguish panic from runtime.Goexit,
+	// more details see https://golang.org/cl/134395
+	defer func() {
+		// the given function invoked runtime.Goexit
+		if !normalReturn && !recovered {
+			c.err = errGoexit
+		}
+
+		c.wg.Done()
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if !c.forgotten {
+			delete(g.m, key)
+		}
+
+		for _, ch := range c.chans {
+			ch <- Result{c.val, c.err, c.dups > 0}
+			close(ch)
+		}
+
+		if e, ok := c.err.(*panicError); ok {
+			// In order to prevent the waiting channels from being
+			// blocked forever, needs to ensure that this panic cannot
+			// be recovered.
+			if len(c.chans) > 0 {
+				go panic(e)
+				select {} // Keep this goroutine around so that it will appear in the crash dump.
+			} else {
+				panic(e)
+			}
+		} else if c.err == errGoexit {
+			// Already in the process of goexit, no need to call again
+		}
+	}()
+
+	func() {
+		defer func() {
+			if !normalReturn {
+				// Ideally, we would wait to take a stack trace until we've
+				// determined whether this is a panic or a runtime.Goexit.
+				//
+				// Unfortunately, the only way we can distinguish the two is
+				// to see whether the recover stopped the goroutine from
+				// terminating, and by the time we know that, the part of
+				// the stack trace relevant to the panic has been discarded.
+				if r := recover(); r != nil {
+					c.err = newPanicError(r)
+				}
+			}
+		}()
+
+		c.val, c.err = fn() // 执行函数，并将结果保存在call中
+		normalReturn = true
+	}()
+
+	if !normalReturn {
+		recovered = true
+	}
+}
+
+// Forget tells the group to forget about a key. Future calls to Do (or
+// DoChan) for this key will call fn rather than waiting for an
+// earlier call to complete. Useful when an in-flight (or just
+// completed) loader is known to have returned stale data.
+// Forget让Group忘记指定的key，后续对该key的Do/DoChan调用会重新执行fn而不是复用之前的结果，
+// 适用于已知某次加载返回了过期数据的场景
+func (g *Group) Forget(key string) {
+	g.mu.Lock()
+	if c, ok := g.m[key]; ok {
+		c.forgotten = true
+	}
+	delete(g.m, key)
+	g.mu.Unlock()
 }
@@ -0,0 +1,235 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	var g Group
+	v, err := g.Do("key", func() (interface{}, error) {
+		return "bar", nil
+	})
+	if v != "bar" {
+		t.Errorf("Do value = %v, want \"bar\"", v)
+	}
+	if err != nil {
+		t.Errorf("Do error = %v, want nil", err)
+	}
+}
+
+// waitForDups blocks until the in-flight call for key has at least want
+// duplicate callers registered, so a test can join a call as a
+// duplicate deterministically instead of racing a background goroutine
+// against the leader's completion.
+func waitForDups(t *testing.T, g *Group, key string, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		g.mu.Lock()
+		c, ok := g.m[key]
+		dups := 0
+		if ok {
+			dups = c.dups
+		}
+		g.mu.Unlock()
+		if dups >= want {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d duplicate callers on %q", want, key)
+}
+
+func TestDoDupSuppress(t *testing.T) {
+	var g Group
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, 2)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		v, _ := g.Do("key", func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			close(started)
+			<-release
+			return "bar", nil
+		})
+		results[0] = v
+	}()
+	<-started
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		v, _ := g.Do("key", func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return "bar", nil
+		})
+		results[1] = v
+	}()
+	waitForDups(t, &g, "key", 1)
+
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 (duplicate call should be suppressed)", calls)
+	}
+	for i, v := range results {
+		if v != "bar" {
+			t.Errorf("results[%d] = %v, want \"bar\"", i, v)
+		}
+	}
+}
+
+func TestDoChan(t *testing.T) {
+	var g Group
+	ch := g.DoChan("key", func() (interface{}, error) {
+		return "bar", nil
+	})
+
+	select {
+	case r := <-ch:
+		if r.Val != "bar" || r.Err != nil {
+			t.Fatalf("DoChan result = %+v, want {Val: bar, Err: nil}", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("DoChan channel never received a result")
+	}
+}
+
+func TestDoContextCancelDoesNotStopSharedCall(t *testing.T) {
+	var g Group
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		g.Do("key", func() (interface{}, error) {
+			close(started)
+			<-release
+			return "bar", nil
+		})
+		close(done)
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := g.DoContext(ctx, "key", func() (interface{}, error) {
+		t.Fatal("fn should not run again for an in-flight key")
+		return nil, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("DoContext err = %v, want context.Canceled", err)
+	}
+
+	select {
+	case <-done:
+		t.Fatal("shared call finished before being released, want it still in flight")
+	default:
+	}
+	close(release)
+	<-done
+}
+
+func TestForgetStartsFreshCall(t *testing.T) {
+	var g Group
+	g.Forget("key") // forgetting an unknown key is a no-op
+
+	first := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		g.Do("key", func() (interface{}, error) {
+			close(first)
+			<-release
+			return "old", nil
+		})
+		close(done)
+	}()
+	<-first
+
+	g.Forget("key")
+
+	var calls int32
+	v, err := g.Do("key", func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return "new", nil
+	})
+	if err != nil {
+		t.Fatalf("Do error = %v, want nil", err)
+	}
+	if v != "new" {
+		t.Fatalf("Do value = %v, want \"new\" (Forget should start a fresh call)", v)
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+
+	close(release)
+	<-done
+}
+
+func TestDoPanicPropagatesToDuplicateCallers(t *testing.T) {
+	var g Group
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	panicked := make([]bool, 2)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() {
+			if recover() != nil {
+				panicked[0] = true
+			}
+		}()
+		g.Do("key", func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() {
+			if recover() != nil {
+				panicked[1] = true
+			}
+		}()
+		g.Do("key", func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return nil, nil
+		})
+	}()
+	waitForDups(t, &g, "key", 1)
+
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1 (the duplicate caller must not re-invoke fn)", calls)
+	}
+	for i, p := range panicked {
+		if !p {
+			t.Errorf("caller %d did not observe the panic, want it re-raised", i)
+		}
+	}
+}